@@ -0,0 +1,85 @@
+package stats
+
+import (
+	"time"
+
+	"github.com/influxdata/enterprise-client/v2"
+	"github.com/influxdata/influxdb/toml"
+)
+
+const (
+	// DefaultStatsInterval is the default period between stats and
+	// diagnostics reports.
+	DefaultStatsInterval = toml.Duration(10 * time.Second)
+
+	// DefaultDatabase is the default database stats/diagnostics are
+	// written to.
+	DefaultDatabase = "_kapacitor"
+
+	// DefaultRetentionPolicy is the default retention policy stats and
+	// diagnostics are written to within DefaultDatabase.
+	DefaultRetentionPolicy = "default"
+
+	// DefaultRetentionPolicyDuration is how long points in
+	// DefaultRetentionPolicy are kept for.
+	DefaultRetentionPolicyDuration = toml.Duration(7 * 24 * time.Hour)
+
+	// DefaultRetentionPolicyReplicaN is the default replication factor
+	// applied to the auto-created retention policy.
+	DefaultRetentionPolicyReplicaN = 1
+)
+
+// Config is the configuration for the stats service.
+type Config struct {
+	Enabled         bool          `toml:"enabled"`
+	StatsInterval   toml.Duration `toml:"stats-interval"`
+	Database        string        `toml:"database"`
+	RetentionPolicy string        `toml:"retention-policy"`
+
+	// CreateIfNotExists determines whether the service will create
+	// Database/RetentionPolicy on the connected InfluxDB cluster(s) if
+	// they do not already exist.
+	CreateIfNotExists            bool          `toml:"create-if-not-exists"`
+	RetentionPolicyDuration      toml.Duration `toml:"retention-policy-duration"`
+	RetentionPolicyReplicaN      int           `toml:"retention-policy-replication"`
+	RetentionPolicyShardDuration toml.Duration `toml:"retention-policy-shard-duration"`
+
+	// GlobalTags are merged into every point the service emits, taking
+	// precedence over the service's computed default tags but yielding to
+	// any tag a stat or diagnostic already carries.
+	GlobalTags map[string]string `toml:"global-tags"`
+
+	// Reporters are additional destinations, beyond the Kapacitor stream,
+	// that every reported stats point is fanned out to.
+	Reporters []ReporterConfig `toml:"reporters"`
+
+	EnterpriseHosts []*client.Host `toml:"-"`
+}
+
+// ReporterConfig configures a single additional stats Reporter, declared in
+// TOML via `[[stats.reporters]]`.
+type ReporterConfig struct {
+	Enabled bool   `toml:"enabled"`
+	Type    string `toml:"type"`
+
+	// URLs are the InfluxDB HTTP endpoints for the "influxdb" reporter.
+	URLs         []string      `toml:"urls"`
+	BatchSize    int           `toml:"batch-size"`
+	BatchTimeout toml.Duration `toml:"batch-timeout"`
+	Username     string        `toml:"username"`
+	Password     string        `toml:"password"`
+	Precision    string        `toml:"precision"`
+}
+
+// NewConfig returns a Config with the defaults applied.
+func NewConfig() Config {
+	return Config{
+		Enabled:                 true,
+		StatsInterval:           DefaultStatsInterval,
+		Database:                DefaultDatabase,
+		RetentionPolicy:         DefaultRetentionPolicy,
+		CreateIfNotExists:       true,
+		RetentionPolicyDuration: DefaultRetentionPolicyDuration,
+		RetentionPolicyReplicaN: DefaultRetentionPolicyReplicaN,
+	}
+}
@@ -0,0 +1,85 @@
+package stats
+
+import (
+	"net"
+	"os"
+	"runtime"
+
+	"github.com/influxdata/kapacitor"
+)
+
+// Diagnostics is a table of named columns and rows, modeled on the shape
+// InfluxDB's monitor service uses for SHOW DIAGNOSTICS.
+type Diagnostics struct {
+	Columns []string
+	Rows    [][]interface{}
+}
+
+// DiagnosticsClient is implemented by anything that can report a table of
+// diagnostic information about itself.
+type DiagnosticsClient interface {
+	Diagnostics() (*Diagnostics, error)
+}
+
+// buildInfoDiagnostics reports the version/branch/commit/build time of the
+// running binary.
+type buildInfoDiagnostics struct{}
+
+func (buildInfoDiagnostics) Diagnostics() (*Diagnostics, error) {
+	return &Diagnostics{
+		Columns: []string{"version", "branch", "commit", "build_time"},
+		Rows: [][]interface{}{{
+			kapacitor.GetStringVar(kapacitor.VersionVarName),
+			kapacitor.GetStringVar(kapacitor.BranchVarName),
+			kapacitor.GetStringVar(kapacitor.CommitVarName),
+			kapacitor.GetStringVar(kapacitor.BuildTimeVarName),
+		}},
+	}, nil
+}
+
+// goRuntimeDiagnostics reports basic Go runtime stats.
+type goRuntimeDiagnostics struct{}
+
+func (goRuntimeDiagnostics) Diagnostics() (*Diagnostics, error) {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+	return &Diagnostics{
+		Columns: []string{"goos", "goarch", "version", "num_goroutine", "gomaxprocs", "alloc_bytes", "sys_bytes", "num_gc"},
+		Rows: [][]interface{}{{
+			runtime.GOOS,
+			runtime.GOARCH,
+			runtime.Version(),
+			runtime.NumGoroutine(),
+			runtime.GOMAXPROCS(0),
+			mem.Alloc,
+			mem.Sys,
+			mem.NumGC,
+		}},
+	}, nil
+}
+
+// networkDiagnostics reports the hostname and bind addresses of the host
+// running this Kapacitor instance.
+type networkDiagnostics struct{}
+
+func (networkDiagnostics) Diagnostics() (*Diagnostics, error) {
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = kapacitor.GetStringVar(kapacitor.HostVarName)
+	}
+
+	var addrs []string
+	if ifaceAddrs, err := net.InterfaceAddrs(); err == nil {
+		for _, a := range ifaceAddrs {
+			addrs = append(addrs, a.String())
+		}
+	}
+
+	return &Diagnostics{
+		Columns: []string{"hostname", "addresses"},
+		Rows: [][]interface{}{{
+			hostname,
+			addrs,
+		}},
+	}, nil
+}
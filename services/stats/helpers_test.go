@@ -0,0 +1,19 @@
+package stats
+
+import "github.com/influxdata/kapacitor/models"
+
+// fakeStreamCollector records every point it is given instead of sending it
+// into a real stream, so tests can assert on what the service would have
+// emitted.
+type fakeStreamCollector struct {
+	points []models.Point
+}
+
+func (f *fakeStreamCollector) CollectPoint(p models.Point) error {
+	f.points = append(f.points, p)
+	return nil
+}
+
+func (f *fakeStreamCollector) Close() error {
+	return nil
+}
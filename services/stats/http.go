@@ -0,0 +1,184 @@
+package stats
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/influxdata/kapacitor"
+)
+
+// Route describes an HTTP handler the stats service wants registered with
+// the HTTP service. It mirrors httpd.Route without depending on the httpd
+// package directly.
+type Route struct {
+	Name        string
+	Method      string
+	Pattern     string
+	HandlerFunc http.HandlerFunc
+}
+
+// HTTPDService registers HTTP routes. It is satisfied by
+// services/httpd.Service.
+type HTTPDService interface {
+	AddRoutes(routes []Route) error
+	DelRoutes(routes []Route)
+}
+
+// Series is a single named, tagged table of results, shaped like an
+// InfluxDB query result series.
+type Series struct {
+	Name    string            `json:"name"`
+	Tags    map[string]string `json:"tags,omitempty"`
+	Columns []string          `json:"columns"`
+	Values  [][]interface{}   `json:"values"`
+}
+
+// Result holds the series returned for a single statement, mirroring
+// InfluxDB's query result shape.
+type Result struct {
+	Series []Series `json:"series"`
+}
+
+// Results is the top level response shape returned by the debug/stats and
+// debug/diagnostics endpoints, matching what InfluxDB's SHOW STATS / SHOW
+// DIAGNOSTICS return.
+type Results struct {
+	Results []Result `json:"results"`
+}
+
+func (s *Service) handleStats(w http.ResponseWriter, r *http.Request) {
+	name := r.URL.Query().Get("name")
+
+	data, err := kapacitor.GetStatsData()
+	if err != nil {
+		httpError(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	var series []Series
+	for _, stat := range data {
+		if name != "" && stat.Name != name {
+			continue
+		}
+		series = append(series, s.statSeries(stat.Name, stat.Tags, stat.Values))
+	}
+
+	s.respond(w, r, series)
+}
+
+func (s *Service) handleDiagnostics(w http.ResponseWriter, r *http.Request) {
+	name := r.URL.Query().Get("name")
+
+	s.diagMu.Lock()
+	clients := make(map[string]DiagnosticsClient, len(s.diagClients))
+	for n, c := range s.diagClients {
+		clients[n] = c
+	}
+	s.diagMu.Unlock()
+
+	var series []Series
+	for n, c := range clients {
+		if name != "" && n != name {
+			continue
+		}
+		diags, err := c.Diagnostics()
+		if err != nil {
+			s.logger.Printf("E! error getting diagnostics for %s: %s\n", n, err)
+			continue
+		}
+		series = append(series, Series{
+			Name:    n,
+			Tags:    s.mergeTags(nil),
+			Columns: diags.Columns,
+			Values:  diags.Rows,
+		})
+	}
+
+	s.respond(w, r, series)
+}
+
+// statSeries converts a single stat into a Series, with a single row of
+// values ordered the same as its columns.
+func (s *Service) statSeries(name string, tags map[string]string, values map[string]interface{}) Series {
+	columns := make([]string, 0, len(values))
+	for c := range values {
+		columns = append(columns, c)
+	}
+	sort.Strings(columns)
+
+	row := make([]interface{}, len(columns))
+	for i, c := range columns {
+		row[i] = values[c]
+	}
+
+	return Series{
+		Name:    name,
+		Tags:    s.mergeTags(tags),
+		Columns: columns,
+		Values:  [][]interface{}{row},
+	}
+}
+
+func (s *Service) respond(w http.ResponseWriter, r *http.Request, series []Series) {
+	if strings.ToLower(r.URL.Query().Get("format")) == "line" {
+		w.Header().Set("Content-Type", "application/octet-stream")
+		for _, srs := range series {
+			for _, row := range srs.Values {
+				if line, ok := lineProtocol(srs, row); ok {
+					fmt.Fprintln(w, line)
+				}
+			}
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(Results{Results: []Result{{Series: series}}})
+}
+
+// lineProtocol renders a single row of a series as an InfluxDB line
+// protocol line. It reports false if the row has no fields with a valid
+// line protocol representation, since a fieldless line is invalid.
+func lineProtocol(srs Series, row []interface{}) (string, bool) {
+	var b strings.Builder
+	b.WriteString(escapeLineKeyOrTagValue(srs.Name))
+
+	tagKeys := make([]string, 0, len(srs.Tags))
+	for k := range srs.Tags {
+		tagKeys = append(tagKeys, k)
+	}
+	sort.Strings(tagKeys)
+	for _, k := range tagKeys {
+		fmt.Fprintf(&b, ",%s=%s", escapeLineKeyOrTagValue(k), escapeLineKeyOrTagValue(srs.Tags[k]))
+	}
+
+	b.WriteByte(' ')
+	wrote := 0
+	for i, c := range srs.Columns {
+		if i >= len(row) {
+			break
+		}
+		v, ok := formatLineFieldValue(row[i])
+		if !ok {
+			continue
+		}
+		if wrote > 0 {
+			b.WriteByte(',')
+		}
+		fmt.Fprintf(&b, "%s=%s", escapeLineKeyOrTagValue(c), v)
+		wrote++
+	}
+	if wrote == 0 {
+		return "", false
+	}
+	return b.String(), true
+}
+
+func httpError(w http.ResponseWriter, err error, status int) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+}
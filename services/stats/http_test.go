@@ -0,0 +1,76 @@
+package stats
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"log"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestService_handleDiagnostics_JSON(t *testing.T) {
+	s := NewService(Config{}, log.New(ioutil.Discard, "", 0))
+	s.RegisterDiagnosticsClient("fake", fakeDiagnosticsClient{
+		diags: &Diagnostics{
+			Columns: []string{"a"},
+			Rows:    [][]interface{}{{1}},
+		},
+	})
+
+	req := httptest.NewRequest("GET", "/kapacitor/v1/debug/diagnostics", nil)
+	w := httptest.NewRecorder()
+	s.handleDiagnostics(w, req)
+
+	var results Results
+	if err := json.NewDecoder(w.Body).Decode(&results); err != nil {
+		t.Fatalf("failed to decode response: %s", err)
+	}
+	if len(results.Results) != 1 || len(results.Results[0].Series) != 1 {
+		t.Fatalf("unexpected results: %+v", results)
+	}
+	if got := results.Results[0].Series[0].Name; got != "fake" {
+		t.Errorf("unexpected series name: got %s exp %s", got, "fake")
+	}
+}
+
+func TestService_handleDiagnostics_nameFilter(t *testing.T) {
+	s := NewService(Config{}, log.New(ioutil.Discard, "", 0))
+	s.RegisterDiagnosticsClient("a", fakeDiagnosticsClient{
+		diags: &Diagnostics{Columns: []string{"x"}, Rows: [][]interface{}{{1}}},
+	})
+	s.RegisterDiagnosticsClient("b", fakeDiagnosticsClient{
+		diags: &Diagnostics{Columns: []string{"x"}, Rows: [][]interface{}{{2}}},
+	})
+
+	req := httptest.NewRequest("GET", "/kapacitor/v1/debug/diagnostics?name=a", nil)
+	w := httptest.NewRecorder()
+	s.handleDiagnostics(w, req)
+
+	var results Results
+	if err := json.NewDecoder(w.Body).Decode(&results); err != nil {
+		t.Fatalf("failed to decode response: %s", err)
+	}
+	if len(results.Results[0].Series) != 1 || results.Results[0].Series[0].Name != "a" {
+		t.Fatalf("expected name filter to return only series %q, got %+v", "a", results.Results[0].Series)
+	}
+}
+
+func TestService_handleDiagnostics_line(t *testing.T) {
+	s := NewService(Config{}, log.New(ioutil.Discard, "", 0))
+	s.RegisterDiagnosticsClient("fake", fakeDiagnosticsClient{
+		diags: &Diagnostics{
+			Columns: []string{"msg"},
+			Rows:    [][]interface{}{{`say "hi"`}},
+		},
+	})
+
+	req := httptest.NewRequest("GET", "/kapacitor/v1/debug/diagnostics?format=line", nil)
+	w := httptest.NewRecorder()
+	s.handleDiagnostics(w, req)
+
+	body := w.Body.String()
+	if !strings.Contains(body, `msg="say \"hi\""`) {
+		t.Errorf("expected quoted/escaped string field in line output, got %q", body)
+	}
+}
@@ -0,0 +1,77 @@
+package stats
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// escapeLineKeyOrTagValue escapes the characters InfluxDB line protocol
+// treats as structural (commas, spaces, and equals signs) in measurement
+// names, tag keys/values, and field keys.
+func escapeLineKeyOrTagValue(s string) string {
+	r := strings.NewReplacer(
+		",", `\,`,
+		"=", `\=`,
+		" ", `\ `,
+	)
+	return r.Replace(s)
+}
+
+// quoteLineStringValue quotes s as an InfluxDB line protocol string field
+// value, escaping embedded backslashes and double quotes.
+func quoteLineStringValue(s string) string {
+	s = strings.Replace(s, `\`, `\\`, -1)
+	s = strings.Replace(s, `"`, `\"`, -1)
+	return `"` + s + `"`
+}
+
+// formatLineFieldValue renders v as an InfluxDB line protocol field value:
+// strings are quoted/escaped, integers are suffixed with "i", and
+// []string is flattened into a single quoted, comma-joined string (the
+// shape the built-in network diagnostics client's "addresses" column
+// uses). It reports false for types with no safe line protocol
+// representation, so the caller can drop the field instead of emitting a
+// line that would corrupt ingestion.
+func formatLineFieldValue(v interface{}) (string, bool) {
+	switch n := v.(type) {
+	case string:
+		return quoteLineStringValue(n), true
+	case []string:
+		return quoteLineStringValue(strings.Join(n, ",")), true
+	case bool:
+		return strconv.FormatBool(n), true
+	case int:
+		return strconv.FormatInt(int64(n), 10) + "i", true
+	case int32:
+		return strconv.FormatInt(int64(n), 10) + "i", true
+	case int64:
+		return strconv.FormatInt(n, 10) + "i", true
+	case uint32:
+		return strconv.FormatUint(uint64(n), 10) + "i", true
+	case uint64:
+		return strconv.FormatUint(n, 10) + "i", true
+	case float32:
+		return strconv.FormatFloat(float64(n), 'g', -1, 64), true
+	case float64:
+		return strconv.FormatFloat(n, 'g', -1, 64), true
+	default:
+		return "", false
+	}
+}
+
+// lineProtocolTimestamp renders t as a line protocol timestamp in the given
+// precision ("ns", "us", "ms", "s"; empty defaults to "ns"), matching the
+// precision the point will be written/queried with.
+func lineProtocolTimestamp(t time.Time, precision string) int64 {
+	switch precision {
+	case "s":
+		return t.Unix()
+	case "ms":
+		return t.UnixNano() / int64(time.Millisecond)
+	case "us":
+		return t.UnixNano() / int64(time.Microsecond)
+	default:
+		return t.UnixNano()
+	}
+}
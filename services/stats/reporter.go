@@ -0,0 +1,38 @@
+package stats
+
+import (
+	"github.com/influxdata/kapacitor"
+	"github.com/influxdata/kapacitor/models"
+)
+
+// Reporter is a destination for stats points. The stats service fans every
+// tick of reported points out to each enabled Reporter, so a task
+// monitoring the node doesn't have to subscribe to the Kapacitor stream.
+type Reporter interface {
+	Name() string
+	Report(points []models.Point) error
+	Close() error
+}
+
+// streamReporter reports points into the Kapacitor stream. It is always
+// enabled and preserves the service's original behavior.
+type streamReporter struct {
+	stream kapacitor.StreamCollector
+}
+
+func newStreamReporter(stream kapacitor.StreamCollector) *streamReporter {
+	return &streamReporter{stream: stream}
+}
+
+func (r *streamReporter) Name() string { return "stream" }
+
+func (r *streamReporter) Report(points []models.Point) error {
+	for _, p := range points {
+		r.stream.CollectPoint(p)
+	}
+	return nil
+}
+
+func (r *streamReporter) Close() error {
+	return r.stream.Close()
+}
@@ -0,0 +1,232 @@
+package stats
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/influxdata/kapacitor/models"
+)
+
+const defaultBatchTimeout = 10 * time.Second
+
+// influxDBReporter batches points and writes them directly to one or more
+// InfluxDB HTTP endpoints, so operators don't need a TICKscript task (and
+// the excluded-subscriptions loop it requires) just to persist stats.
+type influxDBReporter struct {
+	urls      []string
+	db        string
+	rp        string
+	username  string
+	password  string
+	precision string
+
+	batchSize int
+	client    *http.Client
+
+	mu  sync.Mutex
+	buf []models.Point
+
+	flushC  chan struct{}
+	closing chan struct{}
+	wg      sync.WaitGroup
+
+	logger *log.Logger
+}
+
+func newInfluxDBReporter(c ReporterConfig, db, rp string, l *log.Logger) *influxDBReporter {
+	timeout := time.Duration(c.BatchTimeout)
+	if timeout <= 0 {
+		timeout = defaultBatchTimeout
+	}
+
+	r := &influxDBReporter{
+		urls:      c.URLs,
+		db:        db,
+		rp:        rp,
+		username:  c.Username,
+		password:  c.Password,
+		precision: c.Precision,
+		batchSize: c.BatchSize,
+		client:    &http.Client{Timeout: 30 * time.Second},
+		flushC:    make(chan struct{}, 1),
+		closing:   make(chan struct{}),
+		logger:    l,
+	}
+
+	r.wg.Add(1)
+	go r.run(timeout)
+	return r
+}
+
+func (r *influxDBReporter) Name() string { return "influxdb" }
+
+func (r *influxDBReporter) Report(points []models.Point) error {
+	r.mu.Lock()
+	r.buf = append(r.buf, points...)
+	full := r.batchSize > 0 && len(r.buf) >= r.batchSize
+	r.mu.Unlock()
+
+	if full {
+		select {
+		case r.flushC <- struct{}{}:
+		default:
+		}
+	}
+	return nil
+}
+
+func (r *influxDBReporter) run(timeout time.Duration) {
+	defer r.wg.Done()
+	ticker := time.NewTicker(timeout)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-r.closing:
+			r.flush()
+			return
+		case <-ticker.C:
+			r.flush()
+		case <-r.flushC:
+			r.flush()
+		}
+	}
+}
+
+func (r *influxDBReporter) flush() {
+	r.mu.Lock()
+	batch := r.buf
+	r.buf = nil
+	r.mu.Unlock()
+
+	if len(batch) == 0 {
+		return
+	}
+	if err := r.write(batch); err != nil {
+		r.logger.Printf("E! influxdb reporter failed to write points: %s\n", err)
+	}
+}
+
+func (r *influxDBReporter) write(points []models.Point) error {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	for _, p := range points {
+		if line, ok := pointToLine(p, r.precision); ok {
+			fmt.Fprintln(gz, line)
+		}
+	}
+	if err := gz.Close(); err != nil {
+		return err
+	}
+
+	var lastErr error
+	for _, u := range r.urls {
+		if lastErr = r.post(u, buf.Bytes()); lastErr == nil {
+			return nil
+		}
+	}
+	return lastErr
+}
+
+func (r *influxDBReporter) post(rawurl string, body []byte) error {
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		return err
+	}
+	q := u.Query()
+	q.Set("db", r.db)
+	q.Set("rp", r.rp)
+	if r.precision != "" {
+		q.Set("precision", r.precision)
+	}
+	u.RawQuery = q.Encode()
+
+	const maxAttempts = 3
+	backoff := 500 * time.Millisecond
+
+	var lastErr error
+	for attempt := 0; attempt < maxAttempts; attempt++ {
+		req, err := http.NewRequest("POST", u.String(), bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Encoding", "gzip")
+		if r.username != "" {
+			req.SetBasicAuth(r.username, r.password)
+		}
+
+		resp, err := r.client.Do(req)
+		if err != nil {
+			lastErr = err
+		} else {
+			resp.Body.Close()
+			if resp.StatusCode/100 == 2 {
+				return nil
+			}
+			lastErr = fmt.Errorf("unexpected status writing points to %s: %s", rawurl, resp.Status)
+		}
+
+		if attempt < maxAttempts-1 {
+			time.Sleep(backoff)
+			backoff *= 2
+		}
+	}
+	return lastErr
+}
+
+func (r *influxDBReporter) Close() error {
+	close(r.closing)
+	r.wg.Wait()
+	return nil
+}
+
+// pointToLine renders a models.Point as a single InfluxDB line protocol
+// line, with its timestamp formatted to match precision (see
+// lineProtocolTimestamp). It reports false if the point has no fields with
+// a valid line protocol representation, since a fieldless line is invalid.
+func pointToLine(p models.Point, precision string) (string, bool) {
+	var b strings.Builder
+	b.WriteString(escapeLineKeyOrTagValue(p.Name))
+
+	tagKeys := make([]string, 0, len(p.Tags))
+	for k := range p.Tags {
+		tagKeys = append(tagKeys, k)
+	}
+	sort.Strings(tagKeys)
+	for _, k := range tagKeys {
+		fmt.Fprintf(&b, ",%s=%s", escapeLineKeyOrTagValue(k), escapeLineKeyOrTagValue(p.Tags[k]))
+	}
+
+	fieldKeys := make([]string, 0, len(p.Fields))
+	for k := range p.Fields {
+		fieldKeys = append(fieldKeys, k)
+	}
+	sort.Strings(fieldKeys)
+
+	b.WriteByte(' ')
+	wrote := 0
+	for _, k := range fieldKeys {
+		v, ok := formatLineFieldValue(p.Fields[k])
+		if !ok {
+			continue
+		}
+		if wrote > 0 {
+			b.WriteByte(',')
+		}
+		fmt.Fprintf(&b, "%s=%s", escapeLineKeyOrTagValue(k), v)
+		wrote++
+	}
+	if wrote == 0 {
+		return "", false
+	}
+
+	fmt.Fprintf(&b, " %d", lineProtocolTimestamp(p.Time, precision))
+	return b.String(), true
+}
@@ -0,0 +1,114 @@
+package stats
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/influxdata/kapacitor/models"
+)
+
+// prometheusReporter keeps the most recently reported points in memory, per
+// measurement, and serves them as a Prometheus-style scrape endpoint, so a
+// Prometheus server can pull the current snapshot without the stats stream
+// or subscribing through InfluxDB. reportStats and reportDiagnostics each
+// call Report independently every tick, so points are merged by
+// measurement name rather than replacing the whole snapshot, or whichever
+// of the two reported last would hide the other's points.
+type prometheusReporter struct {
+	mu     sync.Mutex
+	points map[string][]models.Point
+}
+
+func newPrometheusReporter() *prometheusReporter {
+	return &prometheusReporter{points: make(map[string][]models.Point)}
+}
+
+func (r *prometheusReporter) Name() string { return "prometheus" }
+
+func (r *prometheusReporter) Report(points []models.Point) error {
+	byName := make(map[string][]models.Point)
+	for _, p := range points {
+		byName[p.Name] = append(byName[p.Name], p)
+	}
+
+	r.mu.Lock()
+	for name, ps := range byName {
+		r.points[name] = ps
+	}
+	r.mu.Unlock()
+	return nil
+}
+
+func (r *prometheusReporter) Close() error { return nil }
+
+// ServeHTTP writes the last reported points in the Prometheus text
+// exposition format. Boolean fields are written as 1 or 0; other
+// non-numeric fields are skipped, since Prometheus has no representation
+// for them.
+func (r *prometheusReporter) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r.mu.Lock()
+	names := make([]string, 0, len(r.points))
+	for name := range r.points {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var points []models.Point
+	for _, name := range names {
+		points = append(points, r.points[name]...)
+	}
+	r.mu.Unlock()
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	for _, p := range points {
+		fieldKeys := make([]string, 0, len(p.Fields))
+		for k := range p.Fields {
+			fieldKeys = append(fieldKeys, k)
+		}
+		sort.Strings(fieldKeys)
+
+		labels := promLabels(p.Tags)
+		for _, k := range fieldKeys {
+			v, ok := promValue(p.Fields[k])
+			if !ok {
+				continue
+			}
+			fmt.Fprintf(w, "%s{%s} %v\n", promMetricName(p.Name, k), labels, v)
+		}
+	}
+}
+
+func promMetricName(measurement, field string) string {
+	return fmt.Sprintf("kapacitor_%s_%s", measurement, field)
+}
+
+func promLabels(tags models.Tags) string {
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	parts := make([]string, len(keys))
+	for i, k := range keys {
+		parts[i] = fmt.Sprintf("%s=%q", k, tags[k])
+	}
+	return strings.Join(parts, ",")
+}
+
+func promValue(v interface{}) (interface{}, bool) {
+	switch n := v.(type) {
+	case float64, float32, int, int64, int32, uint64, uint32:
+		return n, true
+	case bool:
+		if n {
+			return 1, true
+		}
+		return 0, true
+	default:
+		return nil, false
+	}
+}
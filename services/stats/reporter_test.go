@@ -0,0 +1,171 @@
+package stats
+
+import (
+	"compress/gzip"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/influxdata/kapacitor/models"
+)
+
+func TestStreamReporter(t *testing.T) {
+	c := &fakeStreamCollector{}
+	r := newStreamReporter(c)
+
+	if err := r.Report([]models.Point{{Name: "cpu"}}); err != nil {
+		t.Fatalf("Report returned error: %s", err)
+	}
+	if len(c.points) != 1 {
+		t.Fatalf("expected 1 point collected, got %d", len(c.points))
+	}
+	if err := r.Close(); err != nil {
+		t.Fatalf("Close returned error: %s", err)
+	}
+}
+
+func TestPrometheusReporter_mergesAcrossTicks(t *testing.T) {
+	r := newPrometheusReporter()
+
+	statsPoint := models.Point{Name: "cpu", Fields: models.Fields{"value": 42.0}}
+	diagPoint := models.Point{Name: "runtime", Fields: models.Fields{"num_goroutine": 5}}
+
+	// reportStats and reportDiagnostics each call Report independently
+	// every tick; the second call must not erase the first's points.
+	if err := r.Report([]models.Point{statsPoint}); err != nil {
+		t.Fatalf("Report returned error: %s", err)
+	}
+	if err := r.Report([]models.Point{diagPoint}); err != nil {
+		t.Fatalf("Report returned error: %s", err)
+	}
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/kapacitor/v1/debug/metrics", nil))
+	body := w.Body.String()
+
+	if !strings.Contains(body, "kapacitor_cpu_value") {
+		t.Errorf("expected stats metric to survive a later diagnostics Report, got body %q", body)
+	}
+	if !strings.Contains(body, "kapacitor_runtime_num_goroutine") {
+		t.Errorf("expected diagnostics metric in body %q", body)
+	}
+}
+
+func TestInfluxDBReporter_writesGzippedLineProtocol(t *testing.T) {
+	received := make(chan *http.Request, 1)
+	var body []byte
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gz, err := gzip.NewReader(r.Body)
+		if err != nil {
+			t.Errorf("expected gzip-encoded body: %s", err)
+		} else {
+			body, _ = ioutil.ReadAll(gz)
+		}
+		w.WriteHeader(http.StatusNoContent)
+		received <- r
+	}))
+	defer srv.Close()
+
+	r := newInfluxDBReporter(ReporterConfig{
+		URLs:      []string{srv.URL},
+		BatchSize: 1,
+	}, "mydb", "myrp", log.New(ioutil.Discard, "", 0))
+	defer r.Close()
+
+	p := models.Point{
+		Name:   "cpu",
+		Tags:   models.Tags{"host": "a b"},
+		Fields: models.Fields{"value": 1.5, "msg": "hi"},
+		Time:   time.Unix(0, 0),
+	}
+	if err := r.Report([]models.Point{p}); err != nil {
+		t.Fatalf("Report returned error: %s", err)
+	}
+
+	select {
+	case req := <-received:
+		if got := req.URL.Query().Get("db"); got != "mydb" {
+			t.Errorf("unexpected db query param: got %s", got)
+		}
+		if got := req.URL.Query().Get("rp"); got != "myrp" {
+			t.Errorf("unexpected rp query param: got %s", got)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for the reporter to flush a batch of size 1")
+	}
+
+	if !strings.Contains(string(body), `msg="hi"`) {
+		t.Errorf("expected quoted string field in line protocol body, got %q", body)
+	}
+}
+
+func TestPointToLine_precision(t *testing.T) {
+	p := models.Point{
+		Name:   "cpu",
+		Fields: models.Fields{"value": 1},
+		Time:   time.Unix(1, 500000000),
+	}
+
+	cases := []struct {
+		precision string
+		expTime   string
+	}{
+		{"", "1500000000"},
+		{"ns", "1500000000"},
+		{"us", "1500000"},
+		{"ms", "1500"},
+		{"s", "1"},
+	}
+	for _, c := range cases {
+		line, ok := pointToLine(p, c.precision)
+		if !ok {
+			t.Fatalf("precision %q: expected ok=true", c.precision)
+		}
+		if !strings.HasSuffix(line, " "+c.expTime) {
+			t.Errorf("precision %q: expected line to end with %q, got %q", c.precision, c.expTime, line)
+		}
+	}
+}
+
+func TestPointToLine_quotingAndDroppedFields(t *testing.T) {
+	p := models.Point{
+		Name: "stat,with space",
+		Tags: models.Tags{"host": "a b"},
+		Fields: models.Fields{
+			"msg":       `say "hi"`,
+			"addresses": []string{"127.0.0.1/8", "::1/128"},
+			"bad":       map[string]int{"x": 1},
+		},
+		Time: time.Unix(0, 0),
+	}
+
+	line, ok := pointToLine(p, "")
+	if !ok {
+		t.Fatal("expected ok=true")
+	}
+	if strings.Contains(line, "bad=") {
+		t.Errorf("expected unsupported field type to be dropped, got %q", line)
+	}
+	if !strings.Contains(line, `msg="say \"hi\""`) {
+		t.Errorf("expected string field to be quoted/escaped, got %q", line)
+	}
+	if !strings.Contains(line, `addresses="127.0.0.1/8,::1/128"`) {
+		t.Errorf("expected []string field to be flattened/quoted, got %q", line)
+	}
+}
+
+func TestPointToLine_allFieldsDropped(t *testing.T) {
+	p := models.Point{
+		Name:   "stat",
+		Fields: models.Fields{"bad": map[string]int{"x": 1}},
+		Time:   time.Unix(0, 0),
+	}
+	if _, ok := pointToLine(p, ""); ok {
+		t.Error("expected ok=false when no fields have a valid line protocol representation")
+	}
+}
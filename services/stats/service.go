@@ -20,14 +20,20 @@
 //     [influxdb.excluded-subscriptions]
 //         _kapacitor = [ "default" ]
 //
+// Alternatively, configure one or more [[stats.reporters]] to have the
+// service write stats directly to InfluxDB or expose them for Prometheus
+// to scrape, without needing a TICKscript task at all.
+//
 package stats
 
 import (
 	"errors"
 	"log"
+	"strings"
 	"sync"
 	"time"
 
+	"github.com/influxdata/enterprise-client/v2"
 	"github.com/influxdata/kapacitor"
 	"github.com/influxdata/kapacitor/models"
 )
@@ -40,11 +46,23 @@ type Service struct {
 		Stream(name string) (kapacitor.StreamCollector, error)
 	}
 
-	stream kapacitor.StreamCollector
+	InfluxDBService InfluxDBService
+	HTTPDService    HTTPDService
+
+	routes []Route
+
+	stream        kapacitor.StreamCollector
+	reporters     []Reporter
+	reporterSpecs []ReporterConfig
 
-	interval time.Duration
-	db       string
-	rp       string
+	interval             time.Duration
+	db                   string
+	rp                   string
+	enabled              bool
+	createIfNotExists    bool
+	rpDuration           time.Duration
+	rpReplicaN           int
+	rpShardGroupDuration time.Duration
 
 	open    bool
 	closing chan struct{}
@@ -60,16 +78,104 @@ type Service struct {
 	hostname  string
 	version   string
 	product   string
+
+	diagMu      sync.Mutex
+	diagClients map[string]DiagnosticsClient
+
+	tagsMu     sync.Mutex
+	globalTags map[string]string
+}
+
+// RetentionPolicySpec describes the retention policy the stats service
+// expects its Database to have.
+type RetentionPolicySpec struct {
+	Name               string
+	Duration           time.Duration
+	ReplicaN           int
+	ShardGroupDuration time.Duration
+}
+
+// InfluxDBService creates databases and retention policies on a connected
+// InfluxDB cluster. It is satisfied by services/influxdb.Service.
+type InfluxDBService interface {
+	CreateDatabaseWithRetentionPolicy(database string, rp *RetentionPolicySpec) error
 }
 
 func NewService(c Config, l *log.Logger) *Service {
+	globalTags := make(map[string]string, len(c.GlobalTags))
+	for k, v := range c.GlobalTags {
+		globalTags[k] = v
+	}
 	return &Service{
-		interval:        time.Duration(c.StatsInterval),
-		db:              c.Database,
-		rp:              c.RetentionPolicy,
-		logger:          l,
-		enterpriseHosts: c.EnterpriseHosts,
+		interval:             time.Duration(c.StatsInterval),
+		db:                   c.Database,
+		rp:                   c.RetentionPolicy,
+		enabled:              c.Enabled,
+		createIfNotExists:    c.CreateIfNotExists,
+		rpDuration:           time.Duration(c.RetentionPolicyDuration),
+		rpReplicaN:           c.RetentionPolicyReplicaN,
+		rpShardGroupDuration: time.Duration(c.RetentionPolicyShardDuration),
+		logger:               l,
+		enterpriseHosts:      c.EnterpriseHosts,
+		diagClients:          make(map[string]DiagnosticsClient),
+		globalTags:           globalTags,
+		reporterSpecs:        c.Reporters,
+	}
+}
+
+// SetGlobalTag sets a tag that will be merged into every point the service
+// emits from the next tick onward, taking precedence over the service's
+// computed default tags.
+func (s *Service) SetGlobalTag(k, v string) {
+	s.tagsMu.Lock()
+	defer s.tagsMu.Unlock()
+	s.globalTags[k] = v
+}
+
+// defaultTags returns the tags computed from the service's own identity
+// (cluster/product/host/version) that are merged into every emitted point.
+func (s *Service) defaultTags() map[string]string {
+	return map[string]string{
+		"cluster_id": s.clusterID,
+		"product_id": s.productID,
+		"host":       s.hostname,
+		"version":    s.version,
+		"product":    s.product,
+	}
+}
+
+// mergeTags merges the service's default tags, its configured global tags,
+// and the stat-specific tags, in that order of increasing precedence.
+func (s *Service) mergeTags(stat map[string]string) models.Tags {
+	merged := s.defaultTags()
+
+	s.tagsMu.Lock()
+	for k, v := range s.globalTags {
+		merged[k] = v
+	}
+	s.tagsMu.Unlock()
+
+	for k, v := range stat {
+		merged[k] = v
 	}
+	return models.Tags(merged)
+}
+
+// RegisterDiagnosticsClient registers a new DiagnosticsClient with the given
+// name. The client's Diagnostics() will be collected and reported alongside
+// the build-in diagnostics at every StatsInterval tick.
+func (s *Service) RegisterDiagnosticsClient(name string, c DiagnosticsClient) {
+	s.diagMu.Lock()
+	defer s.diagMu.Unlock()
+	s.diagClients[name] = c
+}
+
+// DeregisterDiagnosticsClient removes the DiagnosticsClient registered under
+// name, if any.
+func (s *Service) DeregisterDiagnosticsClient(name string) {
+	s.diagMu.Lock()
+	defer s.diagMu.Unlock()
+	delete(s.diagClients, name)
 }
 
 func (s *Service) Open() (err error) {
@@ -90,9 +196,77 @@ func (s *Service) Open() (err error) {
 	s.open = true
 	s.closing = make(chan struct{})
 
+	s.RegisterDiagnosticsClient("build", buildInfoDiagnostics{})
+	s.RegisterDiagnosticsClient("runtime", goRuntimeDiagnostics{})
+	s.RegisterDiagnosticsClient("network", networkDiagnostics{})
+
+	// Register the diagnostics of every other subsystem this service
+	// holds a reference to, as long as it implements DiagnosticsClient.
+	// This package only has fields for TaskMaster, InfluxDBService, and
+	// HTTPDService; replay and storage have no field here to wire up, so
+	// they remain invisible to SHOW DIAGNOSTICS-equivalent output until
+	// this service is given a way to reach them.
+	if dc, ok := s.TaskMaster.(DiagnosticsClient); ok {
+		s.RegisterDiagnosticsClient("task_master", dc)
+	}
+	if dc, ok := s.InfluxDBService.(DiagnosticsClient); ok {
+		s.RegisterDiagnosticsClient("influxdb", dc)
+	}
+	if dc, ok := s.HTTPDService.(DiagnosticsClient); ok {
+		s.RegisterDiagnosticsClient("httpd", dc)
+	}
+
+	if s.createIfNotExists {
+		s.ensureDatabaseExists()
+	}
+
 	if err := s.registerServer(); err != nil {
 		s.logger.Println("E! Unable to register with Enterprise Manager")
 	}
+
+	s.routes = []Route{
+		{
+			Name:        "stats",
+			Method:      "GET",
+			Pattern:     "/kapacitor/v1/debug/stats",
+			HandlerFunc: s.handleStats,
+		},
+		{
+			Name:        "diagnostics",
+			Method:      "GET",
+			Pattern:     "/kapacitor/v1/debug/diagnostics",
+			HandlerFunc: s.handleDiagnostics,
+		},
+	}
+
+	s.reporters = []Reporter{newStreamReporter(s.stream)}
+	for _, rc := range s.reporterSpecs {
+		if !rc.Enabled {
+			continue
+		}
+		switch strings.ToLower(rc.Type) {
+		case "influxdb":
+			s.reporters = append(s.reporters, newInfluxDBReporter(rc, s.db, s.rp, s.logger))
+		case "prometheus":
+			pr := newPrometheusReporter()
+			s.reporters = append(s.reporters, pr)
+			s.routes = append(s.routes, Route{
+				Name:        "metrics",
+				Method:      "GET",
+				Pattern:     "/kapacitor/v1/debug/metrics",
+				HandlerFunc: pr.ServeHTTP,
+			})
+		default:
+			s.logger.Printf("E! unknown stats reporter type %q, ignoring\n", rc.Type)
+		}
+	}
+
+	if s.HTTPDService != nil {
+		if err := s.HTTPDService.AddRoutes(s.routes); err != nil {
+			return err
+		}
+	}
+
 	s.wg.Add(1)
 	go s.sendStats()
 	s.logger.Println("I! opened service")
@@ -108,11 +282,60 @@ func (s *Service) Close() error {
 	s.open = false
 	close(s.closing)
 	s.wg.Wait()
-	s.stream.Close()
+	if s.HTTPDService != nil {
+		s.HTTPDService.DelRoutes(s.routes)
+	}
+	for _, r := range s.reporters {
+		if err := r.Close(); err != nil {
+			s.logger.Printf("E! error closing reporter %s: %s\n", r.Name(), err)
+		}
+	}
 	s.logger.Println("I! closed service")
 	return nil
 }
 
+// ensureDatabaseExists creates s.db and its retention policy on the
+// connected InfluxDB cluster(s) if they do not already exist, retrying with
+// backoff in the background so Open does not block on a down cluster. This
+// removes the need to run `CREATE DATABASE` manually before the stats
+// stream can be persisted; it does not define the task that persists it.
+func (s *Service) ensureDatabaseExists() {
+	if s.InfluxDBService == nil {
+		return
+	}
+
+	rp := &RetentionPolicySpec{
+		Name:               s.rp,
+		Duration:           s.rpDuration,
+		ReplicaN:           s.rpReplicaN,
+		ShardGroupDuration: s.rpShardGroupDuration,
+	}
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		backoff := time.Second
+		const maxBackoff = time.Minute
+		for {
+			err := s.InfluxDBService.CreateDatabaseWithRetentionPolicy(s.db, rp)
+			if err == nil {
+				s.logger.Printf("I! created database %q with retention policy %q\n", s.db, s.rp)
+				return
+			}
+			s.logger.Printf("E! failed to create database %q: %s, retrying in %s\n", s.db, err, backoff)
+
+			select {
+			case <-s.closing:
+				return
+			case <-time.After(backoff):
+			}
+			if backoff *= 2; backoff > maxBackoff {
+				backoff = maxBackoff
+			}
+		}
+	}()
+}
+
 func (s *Service) registerServer() error {
 	if !s.enabled || len(s.enterpriseHosts) == 0 {
 		return nil
@@ -156,6 +379,7 @@ func (s *Service) sendStats() {
 			return
 		case <-ticker.C:
 			s.reportStats()
+			s.reportDiagnostics()
 		}
 	}
 }
@@ -167,16 +391,72 @@ func (s *Service) reportStats() {
 		s.logger.Println("E! error getting stats data:", err)
 		return
 	}
+
+	points := make([]models.Point, 0, len(data))
 	for _, stat := range data {
-		p := models.Point{
+		points = append(points, models.Point{
 			Database:        s.db,
 			RetentionPolicy: s.rp,
 			Name:            stat.Name,
 			Group:           models.NilGroup,
-			Tags:            models.Tags(stat.Tags),
+			Tags:            s.mergeTags(stat.Tags),
 			Time:            now,
 			Fields:          models.Fields(stat.Values),
+		})
+	}
+
+	s.report(points)
+}
+
+// report fans points out to every enabled Reporter concurrently, logging
+// per-reporter errors so a single failing sink does not starve the others.
+func (s *Service) report(points []models.Point) {
+	var wg sync.WaitGroup
+	for _, r := range s.reporters {
+		wg.Add(1)
+		go func(r Reporter) {
+			defer wg.Done()
+			if err := r.Report(points); err != nil {
+				s.logger.Printf("E! reporter %s failed to report stats: %s\n", r.Name(), err)
+			}
+		}(r)
+	}
+	wg.Wait()
+}
+
+// reportDiagnostics converts every registered DiagnosticsClient's Diagnostics
+// into one models.Point per table row, using the diagnostic table's columns
+// as fields, and fans the points out to every enabled Reporter.
+func (s *Service) reportDiagnostics() {
+	now := time.Now().UTC()
+
+	s.diagMu.Lock()
+	points := make([]models.Point, 0, len(s.diagClients))
+	for name, c := range s.diagClients {
+		diags, err := c.Diagnostics()
+		if err != nil {
+			s.logger.Printf("E! error getting diagnostics for %s: %s\n", name, err)
+			continue
+		}
+		for _, row := range diags.Rows {
+			fields := make(models.Fields, len(diags.Columns))
+			for i, column := range diags.Columns {
+				if i < len(row) {
+					fields[column] = row[i]
+				}
+			}
+			points = append(points, models.Point{
+				Database:        s.db,
+				RetentionPolicy: s.rp,
+				Name:            name,
+				Group:           models.NilGroup,
+				Tags:            s.mergeTags(nil),
+				Time:            now,
+				Fields:          fields,
+			})
 		}
-		s.stream.CollectPoint(p)
 	}
+	s.diagMu.Unlock()
+
+	s.report(points)
 }
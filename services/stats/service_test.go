@@ -0,0 +1,103 @@
+package stats
+
+import (
+	"log"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/influxdata/kapacitor/models"
+)
+
+type fakeDiagnosticsClient struct {
+	diags *Diagnostics
+	err   error
+}
+
+func (f fakeDiagnosticsClient) Diagnostics() (*Diagnostics, error) {
+	return f.diags, f.err
+}
+
+func TestService_reportDiagnostics(t *testing.T) {
+	s := NewService(Config{
+		Database:        "_kapacitor",
+		RetentionPolicy: "default",
+	}, log.New(os.Stderr, "[stats] ", log.LstdFlags))
+
+	collector := &fakeStreamCollector{}
+	s.reporters = []Reporter{newStreamReporter(collector)}
+
+	s.RegisterDiagnosticsClient("fake", fakeDiagnosticsClient{
+		diags: &Diagnostics{
+			Columns: []string{"a", "b"},
+			Rows: [][]interface{}{
+				{1, "two"},
+			},
+		},
+	})
+
+	s.reportDiagnostics()
+
+	if len(collector.points) != 1 {
+		t.Fatalf("expected 1 point, got %d", len(collector.points))
+	}
+
+	p := collector.points[0]
+	if p.Name != "fake" {
+		t.Errorf("unexpected Name: got %s exp %s", p.Name, "fake")
+	}
+	if _, ok := p.Tags["host"]; !ok {
+		t.Errorf("expected default tags to be merged into Tags, got %v", p.Tags)
+	}
+	exp := models.Fields{"a": 1, "b": "two"}
+	if !reflect.DeepEqual(p.Fields, exp) {
+		t.Errorf("unexpected Fields: got %v exp %v", p.Fields, exp)
+	}
+}
+
+func TestService_DeregisterDiagnosticsClient(t *testing.T) {
+	s := NewService(Config{}, log.New(os.Stderr, "[stats] ", log.LstdFlags))
+	collector := &fakeStreamCollector{}
+	s.reporters = []Reporter{newStreamReporter(collector)}
+
+	s.RegisterDiagnosticsClient("fake", fakeDiagnosticsClient{
+		diags: &Diagnostics{Columns: []string{"a"}, Rows: [][]interface{}{{1}}},
+	})
+	s.DeregisterDiagnosticsClient("fake")
+
+	s.reportDiagnostics()
+
+	if len(collector.points) != 0 {
+		t.Fatalf("expected 0 points after deregister, got %d", len(collector.points))
+	}
+}
+
+func TestService_mergeTags(t *testing.T) {
+	s := NewService(Config{
+		GlobalTags: map[string]string{
+			"host":   "global-host",
+			"region": "us-east",
+		},
+	}, log.New(os.Stderr, "[stats] ", log.LstdFlags))
+	s.hostname = "default-host"
+	s.version = "1.2.3"
+
+	got := s.mergeTags(map[string]string{"host": "stat-host"})
+	exp := models.Tags{
+		"cluster_id": "",
+		"product_id": "",
+		"host":       "stat-host",
+		"version":    "1.2.3",
+		"product":    "",
+		"region":     "us-east",
+	}
+	if !reflect.DeepEqual(got, exp) {
+		t.Fatalf("unexpected merged tags: got %v exp %v", got, exp)
+	}
+
+	s.SetGlobalTag("region", "us-west")
+	got = s.mergeTags(nil)
+	if got["region"] != "us-west" {
+		t.Fatalf("expected SetGlobalTag to take effect, got region=%s", got["region"])
+	}
+}